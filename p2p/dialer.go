@@ -0,0 +1,364 @@
+package p2p
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+// DialFlag classifies why a candidate endpoint is in the dial queue, and
+// governs how the Dialer prioritizes and retries it.
+type DialFlag int
+
+const (
+	// DynamicDial marks an endpoint discovered via PEX or similar, dialed
+	// opportunistically to fill the outbound peer budget.
+	DynamicDial DialFlag = 1 << iota
+	// StaticDial marks an endpoint configured as a persistent peer: the
+	// Dialer keeps retrying it, with backoff, for as long as it's added.
+	StaticDial
+	// TrustedDial marks an endpoint configured as a trusted peer or
+	// validator, exempting it from the outbound peer budget.
+	TrustedDial
+)
+
+const (
+	defaultMaxActiveDials   = 16
+	defaultMaxOutboundPeers = 64
+
+	minBackoff    = time.Second
+	maxBackoff    = 10 * time.Minute
+	backoffJitter = 0.25
+)
+
+// dialTask is a single candidate endpoint the Dialer is responsible for
+// connecting to, along with its classification.
+type dialTask struct {
+	id       ID
+	endpoint Endpoint
+	flags    DialFlag
+}
+
+// backoffState tracks the exponential backoff schedule for one peer ID. It
+// is reset on a successful handshake and advanced on every dial failure, so
+// that a flapping peer isn't hammered with reconnect attempts.
+type backoffState struct {
+	failures  int
+	nextRetry time.Time
+}
+
+// next advances the backoff state after a failed dial and returns the time
+// of the next allowed attempt.
+func (b *backoffState) next(now time.Time) time.Time {
+	b.failures++
+	d := minBackoff * time.Duration(uint(1)<<uint(b.failures-1))
+	if d <= 0 || d > maxBackoff {
+		d = maxBackoff
+	}
+	jitter := time.Duration(float64(d) * backoffJitter * (rand.Float64()*2 - 1))
+	b.nextRetry = now.Add(d + jitter)
+	return b.nextRetry
+}
+
+// Dialer schedules outbound dials for the Switch/Router. It maintains a
+// queue of candidate endpoints tagged dynamic/static/trusted, bounds the
+// number of concurrent and total outbound connections, and backs off from
+// peers that repeatedly fail to handshake. It is modeled on go-ethereum's
+// p2p dialstate/dialTask, adapted to tendermint's Transport/Endpoint types.
+//
+// Transport.Dial (mConnTransport's or quicTransport's) remains the
+// low-level primitive that actually opens a connection; the Dialer is what
+// the Switch/Router talks to instead of calling Dial directly, so dialing
+// policy lives in one place regardless of which transport is in use.
+type Dialer struct {
+	logger    log.Logger
+	transport Transport
+	resolver  IPResolver
+
+	maxActiveDials   int
+	maxOutboundPeers int
+
+	scorer        PeerScorer
+	dialThreshold float64
+
+	mtx     sync.Mutex
+	peers   map[ID]*dialTask
+	dialing map[ID]bool
+	// connected tracks the DialFlag each connected peer was added with, so
+	// newTasks can apply maxOutboundPeers to dynamic peers only.
+	connected map[ID]DialFlag
+	backoff   map[ID]*backoffState
+
+	chAdd     chan *dialTask
+	chRemove  chan ID
+	chClose   chan struct{}
+	closeOnce sync.Once
+}
+
+// DialerOption sets an option for a Dialer.
+type DialerOption func(*Dialer)
+
+// DialerMaxActiveDials bounds the number of dials in flight at once.
+func DialerMaxActiveDials(n int) DialerOption {
+	return func(d *Dialer) { d.maxActiveDials = n }
+}
+
+// DialerMaxOutboundPeers bounds the total number of dynamic outbound
+// connections the Dialer will try to maintain. Static and trusted peers are
+// exempt from this budget.
+func DialerMaxOutboundPeers(n int) DialerOption {
+	return func(d *Dialer) { d.maxOutboundPeers = n }
+}
+
+// DialerPeerScorer sets the PeerScorer used to skip dialing candidates whose
+// score has fallen below threshold. threshold is typically set higher than
+// ConnScoreFilter's inbound threshold, since we get to be pickier about who
+// we spend outbound dial attempts on.
+func DialerPeerScorer(scorer PeerScorer, threshold float64) DialerOption {
+	return func(d *Dialer) {
+		d.scorer = scorer
+		d.dialThreshold = threshold
+	}
+}
+
+// NewDialer creates a Dialer that schedules dials against transport,
+// re-resolving static peers via resolver before each attempt.
+func NewDialer(logger log.Logger, transport Transport, resolver IPResolver, opts ...DialerOption) *Dialer {
+	d := &Dialer{
+		logger:    logger,
+		transport: transport,
+		resolver:  resolver,
+
+		maxActiveDials:   defaultMaxActiveDials,
+		maxOutboundPeers: defaultMaxOutboundPeers,
+
+		peers:     make(map[ID]*dialTask),
+		dialing:   make(map[ID]bool),
+		connected: make(map[ID]DialFlag),
+		backoff:   make(map[ID]*backoffState),
+
+		chAdd:    make(chan *dialTask),
+		chRemove: make(chan ID),
+		chClose:  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Add registers endpoint as a dial candidate with the given classification.
+// Adding an endpoint for a peer ID that's already queued replaces it, e.g.
+// when a static peer's configured address changes.
+func (d *Dialer) Add(endpoint Endpoint, flags DialFlag) {
+	select {
+	case d.chAdd <- &dialTask{id: endpoint.PeerID, endpoint: endpoint, flags: flags}:
+	case <-d.chClose:
+	}
+}
+
+// Remove stops the Dialer from dialing or redialing id.
+func (d *Dialer) Remove(id ID) {
+	select {
+	case d.chRemove <- id:
+	case <-d.chClose:
+	}
+}
+
+// Close stops the Dialer's Run loop. It does not close connections already
+// established.
+func (d *Dialer) Close() {
+	d.closeOnce.Do(func() { close(d.chClose) })
+}
+
+// Connected reports a successful handshake for id, resetting its backoff so
+// a peer that misbehaves again starts from the minimum delay. The
+// Switch/Router should call this once a dialed or accepted connection
+// completes its peer handshake; it records the DialFlag id was added with
+// (DynamicDial if id isn't a tracked candidate, e.g. an inbound connection),
+// so maxOutboundPeers can be enforced against dynamic peers only.
+func (d *Dialer) Connected(id ID) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	flags := DynamicDial
+	if task, ok := d.peers[id]; ok {
+		flags = task.flags
+	}
+	d.connected[id] = flags
+	delete(d.dialing, id)
+	delete(d.backoff, id)
+}
+
+// Disconnected reports that a previously connected peer dropped, making it
+// eligible to be redialed, subject to backoff for static/trusted peers.
+func (d *Dialer) Disconnected(id ID) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	delete(d.connected, id)
+}
+
+// dialResult is the outcome of one dial attempt, fed back into Run's loop.
+type dialResult struct {
+	task *dialTask
+	conn Connection
+	err  error
+}
+
+// Run drives the dial loop until ctx is canceled or Close is called: on
+// each tick it computes the next batch of dial tasks via newTasks and
+// executes them against the transport, recording successes and failures
+// into the backoff table.
+func (d *Dialer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(minBackoff)
+	defer ticker.Stop()
+
+	chResult := make(chan dialResult)
+	nRunning := 0
+
+	for {
+		select {
+		case task := <-d.chAdd:
+			d.mtx.Lock()
+			d.peers[task.id] = task
+			d.mtx.Unlock()
+
+		case id := <-d.chRemove:
+			d.mtx.Lock()
+			delete(d.peers, id)
+			delete(d.backoff, id)
+			d.mtx.Unlock()
+
+		case <-ticker.C:
+			for _, task := range d.newTasks(nRunning, time.Now()) {
+				task := task
+				nRunning++
+				d.mtx.Lock()
+				d.dialing[task.id] = true
+				d.mtx.Unlock()
+				go func() {
+					conn, err := d.dial(ctx, task)
+					chResult <- dialResult{task: task, conn: conn, err: err}
+				}()
+			}
+
+		case res := <-chResult:
+			nRunning--
+			d.mtx.Lock()
+			delete(d.dialing, res.task.id)
+			if res.err != nil {
+				d.logger.Debug("dial failed", "peer", res.task.id, "endpoint", res.task.endpoint, "err", res.err)
+				bo := d.backoff[res.task.id]
+				if bo == nil {
+					bo = &backoffState{}
+					d.backoff[res.task.id] = bo
+				}
+				bo.next(time.Now())
+			} else {
+				// FIXME The resulting Connection should be handed off to
+				// the Router/Switch to run the peer lifecycle and call
+				// Connected() once it's actually kept. Until that rewrite
+				// lands, the Dialer closes it below instead of holding it
+				// open -- so it must NOT mark res.task.id connected here,
+				// or newTasks would treat a closed, discarded connection
+				// as permanently satisfied and never redial it. Give it a
+				// short cooldown instead of hammering it every tick.
+				bo := d.backoff[res.task.id]
+				if bo == nil {
+					bo = &backoffState{}
+					d.backoff[res.task.id] = bo
+				}
+				bo.nextRetry = time.Now().Add(minBackoff)
+			}
+			d.mtx.Unlock()
+			if res.conn != nil {
+				_ = res.conn.Close()
+			}
+
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-d.chClose:
+			return nil
+		}
+	}
+}
+
+// dial re-resolves static peers, whose address may have changed since they
+// were added, and then dials the resulting endpoint via the transport.
+func (d *Dialer) dial(ctx context.Context, task *dialTask) (Connection, error) {
+	endpoint := task.endpoint
+	if task.flags&StaticDial != 0 && endpoint.Host != "" && d.resolver != nil {
+		addrs, err := d.resolver.LookupIPAddr(ctx, endpoint.Host)
+		if err == nil && len(addrs) > 0 {
+			endpoint.IP = addrs[0].IP
+			endpoint.Host = "" // Validate rejects an endpoint carrying both
+		}
+	}
+	return d.transport.Dial(ctx, endpoint)
+}
+
+// newTasks returns the next batch of dial tasks to run, mirroring
+// go-ethereum's dialstate algorithm: skip peers that are connected,
+// already dialing, or still backing off; always prefer trusted and static
+// peers; then fill the remaining budget from dynamic (discovered) peers.
+func (d *Dialer) newTasks(nRunning int, now time.Time) []*dialTask {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	slots := d.maxActiveDials - nRunning
+	if slots <= 0 {
+		return nil
+	}
+
+	// maxOutboundPeers only bounds dynamic peers (see DialerMaxOutboundPeers),
+	// so count connected dynamic peers separately from static/trusted ones.
+	dynamicConnected := 0
+	for _, flags := range d.connected {
+		if flags&(StaticDial|TrustedDial) == 0 {
+			dynamicConnected++
+		}
+	}
+
+	var trusted, static, dynamic []*dialTask
+	for id, task := range d.peers {
+		if _, ok := d.connected[id]; ok || d.dialing[id] {
+			continue
+		}
+		if bo, ok := d.backoff[id]; ok && now.Before(bo.nextRetry) {
+			continue
+		}
+		if d.scorer != nil && task.flags&TrustedDial == 0 {
+			if d.scorer.IsBanned(string(id)) || d.scorer.Score(string(id)) < d.dialThreshold {
+				continue
+			}
+		}
+		switch {
+		case task.flags&TrustedDial != 0:
+			trusted = append(trusted, task)
+		case task.flags&StaticDial != 0:
+			static = append(static, task)
+		default:
+			if dynamicConnected >= d.maxOutboundPeers {
+				continue // dynamic peers stop once the outbound budget is full
+			}
+			// Count this candidate against the budget immediately, not just
+			// already-connected peers, so a single batch can't queue more
+			// dynamic dials than maxOutboundPeers allows.
+			dynamicConnected++
+			dynamic = append(dynamic, task)
+		}
+	}
+
+	tasks := make([]*dialTask, 0, slots)
+	for _, bucket := range [][]*dialTask{trusted, static, dynamic} {
+		for _, task := range bucket {
+			if len(tasks) >= slots {
+				return tasks
+			}
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
@@ -0,0 +1,46 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffStateNext(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		failures int
+		wantBase time.Duration
+	}{
+		{1, minBackoff},
+		{2, 2 * minBackoff},
+		{3, 4 * minBackoff},
+		{11, maxBackoff}, // 2^10 * minBackoff = 1024s, genuinely over maxBackoff's 600s
+	}
+	for _, tc := range testCases {
+		// Sanity-check that this case is actually exercising the cap, not
+		// passing only because the jitter window happens to overlap
+		// maxBackoff for an uncapped value below it.
+		uncapped := minBackoff * time.Duration(uint(1)<<uint(tc.failures-1))
+		if tc.wantBase == maxBackoff && uncapped <= maxBackoff {
+			t.Fatalf("failures=%d: uncapped delay %v does not exceed maxBackoff %v, so this case doesn't test saturation", tc.failures, uncapped, maxBackoff)
+		}
+
+		b := &backoffState{failures: tc.failures - 1}
+		next := b.next(now)
+
+		if b.failures != tc.failures {
+			t.Fatalf("failures = %d, want %d", b.failures, tc.failures)
+		}
+
+		minWant := tc.wantBase - time.Duration(float64(tc.wantBase)*backoffJitter)
+		maxWant := tc.wantBase + time.Duration(float64(tc.wantBase)*backoffJitter)
+		delay := next.Sub(now)
+		if delay < minWant || delay > maxWant {
+			t.Fatalf("failures=%d: delay %v outside expected jitter range [%v, %v]", tc.failures, delay, minWant, maxWant)
+		}
+		if next != b.nextRetry {
+			t.Fatalf("next() return value does not match b.nextRetry")
+		}
+	}
+}
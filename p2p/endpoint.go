@@ -0,0 +1,72 @@
+package p2p
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Protocol identifies a transport protocol.
+type Protocol string
+
+// ID is a hex-encoded crypto.Address, used as a node's unique identifier.
+type ID string
+
+// Endpoint represents a node address at the transport layer. A single node
+// may be reachable at multiple endpoints, e.g. one per transport.
+type Endpoint struct {
+	// Protocol is the transport protocol, used by the router to pick a
+	// Transport for dialing.
+	Protocol Protocol
+
+	// Path is an optional, transport-specific path or identifier, used e.g.
+	// by in-memory or Unix-socket transports that have no IP/port.
+	Path string
+
+	// IP is the endpoint's IP address. It is mutually exclusive with Host:
+	// exactly one of the two is set once the endpoint has been resolved.
+	IP net.IP
+
+	// Host is an optional DNS name for the endpoint, used in place of a
+	// literal IP address for persistent peers, seeds, and PEX entries
+	// configured by hostname. It must be resolved to an IP via an
+	// IPResolver before dialing.
+	Host string
+
+	// Port is the endpoint's port number, if any.
+	Port uint16
+
+	// PeerID is the node ID of the peer this endpoint belongs to, if known.
+	PeerID ID
+}
+
+// String formats the endpoint for logging and error messages.
+func (e Endpoint) String() string {
+	host := e.Host
+	if host == "" {
+		host = e.IP.String()
+	}
+	if e.Port > 0 {
+		return fmt.Sprintf("%s://%s:%d", e.Protocol, host, e.Port)
+	}
+	if e.Path != "" {
+		return fmt.Sprintf("%s://%s%s", e.Protocol, host, e.Path)
+	}
+	return fmt.Sprintf("%s://%s", e.Protocol, host)
+}
+
+// Validate validates an endpoint.
+func (e Endpoint) Validate() error {
+	switch {
+	case e.Protocol == "":
+		return errors.New("endpoint has no protocol")
+	case len(e.IP) == 0 && e.Host == "" && e.Path == "":
+		return errors.New("endpoint has no IP, host, or path")
+	case len(e.IP) > 0 && e.Host != "":
+		return errors.New("endpoint cannot have both an IP and a host")
+	case e.Port > 0 && len(e.IP) == 0 && e.Host == "":
+		return errors.New("endpoint has a port but no IP or host")
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,34 @@
+package p2p
+
+import (
+	"net"
+	"testing"
+)
+
+func TestEndpointValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		endpoint Endpoint
+		wantErr  bool
+	}{
+		{"valid IP", Endpoint{Protocol: "mconn", IP: net.ParseIP("1.2.3.4"), Port: 26656}, false},
+		{"valid host", Endpoint{Protocol: "mconn", Host: "example.com", Port: 26656}, false},
+		{"valid path only", Endpoint{Protocol: "memory", Path: "foo"}, false},
+		{"no protocol", Endpoint{IP: net.ParseIP("1.2.3.4")}, true},
+		{"no IP, host, or path", Endpoint{Protocol: "mconn"}, true},
+		{"both IP and host", Endpoint{Protocol: "mconn", IP: net.ParseIP("1.2.3.4"), Host: "example.com"}, true},
+		{"port without IP or host", Endpoint{Protocol: "mconn", Path: "foo", Port: 26656}, true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.endpoint.Validate()
+			if tc.wantErr && err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package nat
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// ExtIP implements Interface for a statically configured external address.
+// No mapping is attempted: it simply assumes the given IP is already
+// reachable from the Internet, e.g. because it was configured on a cloud
+// load balancer or forwarded manually.
+type ExtIP net.IP
+
+// ExternalIP implements Interface.
+func (n ExtIP) ExternalIP() (net.IP, error) { return net.IP(n), nil }
+
+// String implements Interface.
+func (n ExtIP) String() string { return fmt.Sprintf("extip(%v)", net.IP(n)) }
+
+// AddMapping implements Interface. It is a no-op.
+func (ExtIP) AddMapping(string, int, int, string, time.Duration) error { return nil }
+
+// DeleteMapping implements Interface. It is a no-op.
+func (ExtIP) DeleteMapping(string, int, int) error { return nil }
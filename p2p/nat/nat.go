@@ -0,0 +1,165 @@
+// Package nat provides access to common methods for discovering the external
+// (Internet-facing) address of a host and for requesting NAT port mappings so
+// that nodes behind consumer routers can advertise reachable endpoints without
+// manual port forwarding.
+package nat
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Interface is implemented by the supported NAT traversal mechanisms.
+//
+// All methods are safe for concurrent use, since a Transport may refresh a
+// mapping from a background goroutine while the caller reads ExternalIP.
+type Interface interface {
+	// AddMapping maps the given external port to the internal port on this
+	// host for protocol ("tcp" or "udp"), requesting the mapping remain
+	// valid for lifetime. Implementations that use leased mappings (e.g.
+	// NAT-PMP) expect AddMapping to be called again before the lease
+	// expires in order to renew it.
+	AddMapping(protocol string, extPort, intPort int, name string, lifetime time.Duration) error
+
+	// DeleteMapping removes the port mapping for the given external port.
+	DeleteMapping(protocol string, extPort, intPort int) error
+
+	// ExternalIP returns the gateway's external (Internet-facing) IP address.
+	ExternalIP() (net.IP, error)
+
+	// String returns a short human-readable description of the mechanism,
+	// e.g. for logging.
+	String() string
+}
+
+// Parse parses a NAT option string, as found e.g. in a config file's
+// p2p.nat setting. The following forms are recognized:
+//
+//	""                     no NAT traversal
+//	"none"                 no NAT traversal
+//	"extip:<IP>"           assume that IP is externally reachable
+//	"upnp"                 use UPnP IGDv1/IGDv2 port mapping
+//	"pmp"                  use NAT-PMP, discovering the gateway automatically
+//	"pmp:<IP>"             use NAT-PMP against the given gateway
+//	"any"                  try UPnP and NAT-PMP, keep whichever answers first
+func Parse(spec string) (Interface, error) {
+	var (
+		parts = strings.SplitN(spec, ":", 2)
+		mech  = strings.ToLower(parts[0])
+		ip    net.IP
+	)
+	if len(parts) > 1 {
+		ip = net.ParseIP(parts[1])
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q in NAT spec %q", parts[1], spec)
+		}
+	}
+	switch mech {
+	case "", "none", "off":
+		return nil, nil
+	case "any", "auto", "on":
+		return Any(), nil
+	case "extip":
+		if ip == nil {
+			return nil, fmt.Errorf("extip NAT spec requires an IP address: %q", spec)
+		}
+		return ExtIP(ip), nil
+	case "upnp":
+		return UPnP(), nil
+	case "pmp", "natpmp", "nat-pmp":
+		return PMP(ip), nil
+	default:
+		return nil, fmt.Errorf("unknown NAT mechanism %q", spec)
+	}
+}
+
+// Any returns a port mapper that tries to discover any supported NAT
+// traversal mechanism on the local network, racing UPnP and NAT-PMP
+// discovery and keeping whichever protocol answers first.
+func Any() Interface {
+	return startautodisc("any", func() Interface {
+		found := make(chan Interface, 2)
+		go func() { found <- discoverUPnP() }()
+		go func() { found <- discoverPMP() }()
+		for i := 0; i < cap(found); i++ {
+			if c := <-found; c != nil {
+				return c
+			}
+		}
+		return nil
+	})
+}
+
+// UPnP returns a port mapper that discovers a UPnP IGDv1 or IGDv2 gateway on
+// the local network via SSDP multicast.
+func UPnP() Interface {
+	return startautodisc("UPnP", discoverUPnP)
+}
+
+// PMP returns a port mapper that speaks NAT-PMP (RFC 6886) to the given
+// gateway. If ip is nil, the gateway is discovered by probing the default
+// route of each local interface.
+func PMP(ip net.IP) Interface {
+	if ip != nil {
+		return &pmp{gw: ip}
+	}
+	return startautodisc("NAT-PMP", discoverPMP)
+}
+
+// autodisc wraps an Interface whose discovery is deferred to the first call,
+// so that Parse and friends can return immediately without blocking on the
+// network.
+type autodisc struct {
+	what string
+	once sync.Once
+	doit func() Interface
+
+	mu    sync.Mutex
+	found Interface
+}
+
+func startautodisc(what string, doit func() Interface) Interface {
+	return &autodisc{what: what, doit: doit}
+}
+
+func (n *autodisc) AddMapping(protocol string, extPort, intPort int, name string, lifetime time.Duration) error {
+	if err := n.wait(); err != nil {
+		return err
+	}
+	return n.found.AddMapping(protocol, extPort, intPort, name, lifetime)
+}
+
+func (n *autodisc) DeleteMapping(protocol string, extPort, intPort int) error {
+	if err := n.wait(); err != nil {
+		return err
+	}
+	return n.found.DeleteMapping(protocol, extPort, intPort)
+}
+
+func (n *autodisc) ExternalIP() (net.IP, error) {
+	if err := n.wait(); err != nil {
+		return nil, err
+	}
+	return n.found.ExternalIP()
+}
+
+func (n *autodisc) String() string {
+	return n.what
+}
+
+func (n *autodisc) wait() error {
+	n.once.Do(func() {
+		n.mu.Lock()
+		n.found = n.doit()
+		n.mu.Unlock()
+	})
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.found == nil {
+		return fmt.Errorf("no %s router discovered", n.what)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package nat
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	natpmp "github.com/jackpal/go-nat-pmp"
+)
+
+// pmp implements Interface using NAT-PMP (RFC 6886) against a gateway on
+// UDP port 5351.
+type pmp struct {
+	gw     net.IP
+	mu     sync.Mutex
+	client *natpmp.Client
+}
+
+func (n *pmp) natClient() *natpmp.Client {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.client == nil {
+		n.client = natpmp.NewClient(n.gw)
+	}
+	return n.client
+}
+
+// String implements Interface.
+func (n *pmp) String() string {
+	return fmt.Sprintf("NAT-PMP(%v)", n.gw)
+}
+
+// ExternalIP implements Interface, issuing a GetExternalAddress opcode.
+func (n *pmp) ExternalIP() (net.IP, error) {
+	response, err := n.natClient().GetExternalAddress()
+	if err != nil {
+		return nil, err
+	}
+	ip := response.ExternalIPAddress
+	return net.IPv4(ip[0], ip[1], ip[2], ip[3]), nil
+}
+
+// AddMapping implements Interface, issuing an AddPortMapping opcode. An
+// existing mapping for the same port is deleted first, since NAT-PMP
+// gateways otherwise reject a second mapping for the same internal port.
+func (n *pmp) AddMapping(protocol string, extPort, intPort int, name string, lifetime time.Duration) error {
+	_ = n.DeleteMapping(protocol, extPort, intPort)
+	_, err := n.natClient().AddPortMapping(strings.ToLower(protocol), intPort, extPort, int(lifetime/time.Second))
+	return err
+}
+
+// DeleteMapping implements Interface. Per RFC 6886 §3.3.1, a mapping is
+// deleted by requesting it again with a lifetime of zero.
+func (n *pmp) DeleteMapping(protocol string, extPort, intPort int) error {
+	_, err := n.natClient().AddPortMapping(strings.ToLower(protocol), intPort, 0, 0)
+	return err
+}
+
+// discoverPMP probes the default gateway of every local interface in
+// parallel and returns the first one that answers a NAT-PMP request.
+func discoverPMP() Interface {
+	gws := potentialGateways()
+	if len(gws) == 0 {
+		return nil
+	}
+	found := make(chan *pmp, len(gws))
+	for _, gw := range gws {
+		gw := gw
+		go func() {
+			c := natpmp.NewClient(gw)
+			if _, err := c.GetExternalAddress(); err != nil {
+				found <- nil
+				return
+			}
+			found <- &pmp{gw: gw, client: c}
+		}()
+	}
+	for range gws {
+		if c := <-found; c != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+// potentialGateways guesses the default gateway of each local IPv4 interface
+// as the first address of its subnet (commonly .1), which is where consumer
+// routers live.
+func potentialGateways() (gws []net.IP) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipnet, ok := addr.(*net.IPNet)
+			if !ok || ipnet.IP.To4() == nil || ipnet.IP.IsLoopback() {
+				continue
+			}
+			gw := ipnet.IP.Mask(ipnet.Mask).To4()
+			gw[3] |= 0x01
+			gws = append(gws, gw)
+		}
+	}
+	return gws
+}
@@ -0,0 +1,126 @@
+package nat
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/huin/goupnp"
+	"github.com/huin/goupnp/dcps/internetgateway1"
+	"github.com/huin/goupnp/dcps/internetgateway2"
+)
+
+// upnp implements Interface using UPnP IGDv1 or IGDv2, whichever answered
+// discovery first.
+type upnp struct {
+	dev     *goupnp.RootDevice
+	service string
+	client  upnpClient
+}
+
+// upnpClient is satisfied by the WANIPConnection1/2 and WANPPPConnection1
+// SOAP clients generated by goupnp, all of which expose the same three
+// actions we need.
+type upnpClient interface {
+	GetExternalIPAddress() (string, error)
+	AddPortMapping(string, uint16, string, uint16, string, bool, string, uint32) error
+	DeletePortMapping(string, uint16, string) error
+}
+
+// String implements Interface.
+func (n *upnp) String() string {
+	return "UPnP " + n.service
+}
+
+// ExternalIP implements Interface, calling the GetExternalIPAddress SOAP action.
+func (n *upnp) ExternalIP() (net.IP, error) {
+	ipString, err := n.client.GetExternalIPAddress()
+	if err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(ipString)
+	if ip == nil {
+		return nil, fmt.Errorf("upnp: bad IP in GetExternalIPAddress response: %q", ipString)
+	}
+	return ip, nil
+}
+
+// AddMapping implements Interface, calling the AddPortMapping SOAP action
+// against the control URL discovered for the gateway's WANConnectionDevice.
+func (n *upnp) AddMapping(protocol string, extPort, intPort int, name string, lifetime time.Duration) error {
+	ip, err := n.internalAddress()
+	if err != nil {
+		return err
+	}
+	_ = n.DeleteMapping(protocol, extPort, intPort)
+	return n.client.AddPortMapping(
+		"", uint16(extPort), strings.ToUpper(protocol), uint16(intPort),
+		ip.String(), true, name, uint32(lifetime/time.Second),
+	)
+}
+
+// DeleteMapping implements Interface, calling the DeletePortMapping SOAP action.
+func (n *upnp) DeleteMapping(protocol string, extPort, _ int) error {
+	return n.client.DeletePortMapping("", uint16(extPort), strings.ToUpper(protocol))
+}
+
+// internalAddress returns the address of the local interface that routes to
+// the gateway, which is what must be advertised as the mapping's target.
+func (n *upnp) internalAddress() (net.IP, error) {
+	devAddr, err := net.ResolveUDPAddr("udp4", n.dev.URLBase.Host)
+	if err != nil {
+		return nil, err
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && ipnet.Contains(devAddr.IP) {
+				return ipnet.IP, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("upnp: no local address routes to gateway %v", devAddr.IP)
+}
+
+// discoverUPnP runs SSDP discovery (multicast 239.255.255.250:1900) for an
+// IGDv2 gateway and an IGDv1 gateway concurrently, returning whichever
+// WANIPConnection client is found first; IGDv2 is not preferred over IGDv1.
+func discoverUPnP() Interface {
+	found := make(chan *upnp, 2)
+	go discoverIP2(found)
+	go discoverIP1(found)
+	for i := 0; i < cap(found); i++ {
+		if c := <-found; c != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+func discoverIP2(found chan<- *upnp) {
+	clients, _, err := internetgateway2.NewWANIPConnection2Clients()
+	if err != nil || len(clients) == 0 {
+		found <- nil
+		return
+	}
+	c := clients[0]
+	found <- &upnp{service: "IGDv2-IP2", client: c, dev: c.ServiceClient.RootDevice}
+}
+
+func discoverIP1(found chan<- *upnp) {
+	clients, _, err := internetgateway1.NewWANIPConnection1Clients()
+	if err != nil || len(clients) == 0 {
+		found <- nil
+		return
+	}
+	c := clients[0]
+	found <- &upnp{service: "IGDv1-IP1", client: c, dev: c.ServiceClient.RootDevice}
+}
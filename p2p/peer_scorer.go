@@ -0,0 +1,285 @@
+package p2p
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// PeerScoreEvent classifies a negative signal observed about a peer (or, for
+// pre-handshake connections, about an IP address). Each event type carries
+// its own penalty weight.
+type PeerScoreEvent int
+
+const (
+	// EventHandshakeFailure is recorded when the Noise/X25519 or NodeInfo
+	// handshake fails or panics.
+	EventHandshakeFailure PeerScoreEvent = iota
+	// EventProtocolViolation is recorded when MConnection's onError
+	// callback fires for an established connection.
+	EventProtocolViolation
+	// EventDialTimeout is recorded when a dial to a peer times out.
+	EventDialTimeout
+	// EventOversizedMessage is recorded when a peer sends a message larger
+	// than the configured limit.
+	EventOversizedMessage
+	// EventFilterTimeout is recorded when a connection filter callback
+	// fails to respond within the filter timeout.
+	EventFilterTimeout
+)
+
+// eventWeights assigns each event type a penalty, applied as a negative
+// delta to the peer's score.
+var eventWeights = map[PeerScoreEvent]float64{
+	EventHandshakeFailure:  10,
+	EventProtocolViolation: 20,
+	EventDialTimeout:       5,
+	EventOversizedMessage:  15,
+	EventFilterTimeout:     5,
+}
+
+// PeerScorer records reputation-affecting events for peers (keyed by node ID
+// once known, or by IP address beforehand) and exposes a numeric score that
+// ConnScoreFilter and the Dialer use to reject or deprioritize misbehaving
+// peers.
+type PeerScorer interface {
+	// Record applies the penalty for event to key's score.
+	Record(key string, event PeerScoreEvent)
+
+	// Score returns key's current score, decayed for elapsed time. Higher
+	// is better; a key with no recorded events scores 0.
+	Score(key string) float64
+
+	// Ban marks key as banned for duration, so that IsBanned reports true
+	// until it expires. A duration of 0 (or less) bans permanently. Ban does
+	// not change key's score, which keeps decaying as usual.
+	Ban(key string, duration time.Duration)
+
+	// IsBanned reports whether key is currently under a ban.
+	IsBanned(key string) bool
+
+	// Close stops any background persistence and flushes pending state to
+	// disk, if configured. Callers that construct a PeerScorer are
+	// responsible for calling Close on shutdown.
+	Close() error
+}
+
+// persistentPeerScorer is the default PeerScorer. It decays scores toward
+// zero with an exponential moving average so that old infractions matter
+// less over time, and can persist its state to disk so bans survive
+// restarts.
+type persistentPeerScorer struct {
+	mtx       sync.Mutex
+	scores    map[string]*peerScoreEntry
+	halfLife  time.Duration
+	persistTo string
+
+	// dirty marks that scores have changed since the last flush to disk.
+	// Record/Ban mark it instead of writing synchronously, since a score
+	// update happens on every handshake and protocol violation and
+	// shouldn't pay for a disk write each time; a background goroutine
+	// flushes it at flushInterval instead.
+	dirty     bool
+	chClose   chan struct{}
+	chClosed  chan struct{}
+	closeOnce sync.Once
+}
+
+type peerScoreEntry struct {
+	Score       float64   `json:"score"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	BannedUntil time.Time `json:"banned_until,omitempty"`
+	Permanent   bool      `json:"permanent,omitempty"`
+}
+
+// defaultScoreHalfLife is how long it takes a peer's accumulated penalty to
+// decay by half, absent further infractions.
+const defaultScoreHalfLife = 24 * time.Hour
+
+// flushInterval is how often a persistentPeerScorer with persistence
+// configured writes its dirty state to disk, instead of doing so
+// synchronously on every Record/Ban call.
+const flushInterval = 10 * time.Second
+
+// NewPeerScorer creates a PeerScorer whose scores decay with the given half
+// life (defaultScoreHalfLife if zero) and, if persistTo is non-empty, are
+// loaded from and periodically saved to that file so bans survive restarts.
+// Call Close to stop the background flush and persist any final state.
+func NewPeerScorer(halfLife time.Duration, persistTo string) PeerScorer {
+	if halfLife <= 0 {
+		halfLife = defaultScoreHalfLife
+	}
+	s := &persistentPeerScorer{
+		scores:    make(map[string]*peerScoreEntry),
+		halfLife:  halfLife,
+		persistTo: persistTo,
+		chClose:   make(chan struct{}),
+		chClosed:  make(chan struct{}),
+	}
+	if persistTo != "" {
+		_ = s.load()
+		go s.flushLoop()
+	} else {
+		close(s.chClosed)
+	}
+	return s
+}
+
+// flushLoop periodically persists dirty scores to disk until Close is
+// called, so Record/Ban don't pay for a disk write on every call.
+func (s *persistentPeerScorer) flushLoop() {
+	defer close(s.chClosed)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.chClose:
+			s.flush()
+			return
+		}
+	}
+}
+
+// flush writes scores to disk if they've changed since the last flush.
+func (s *persistentPeerScorer) flush() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if !s.dirty {
+		return
+	}
+	s.saveLocked()
+	s.dirty = false
+}
+
+// Close implements PeerScorer.
+func (s *persistentPeerScorer) Close() error {
+	s.closeOnce.Do(func() { close(s.chClose) })
+	<-s.chClosed
+	return nil
+}
+
+// decayedScore returns e's score decayed for the time elapsed since
+// UpdatedAt, without mutating e.
+func (s *persistentPeerScorer) decayedScore(e *peerScoreEntry, now time.Time) float64 {
+	elapsed := now.Sub(e.UpdatedAt)
+	if elapsed <= 0 {
+		return e.Score
+	}
+	decay := math.Exp(-math.Ln2 * float64(elapsed) / float64(s.halfLife))
+	return e.Score * decay
+}
+
+// Record implements PeerScorer.
+func (s *persistentPeerScorer) Record(key string, event PeerScoreEvent) {
+	now := time.Now()
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	e, ok := s.scores[key]
+	if !ok {
+		e = &peerScoreEntry{}
+		s.scores[key] = e
+	}
+	e.Score = s.decayedScore(e, now) - eventWeights[event]
+	e.UpdatedAt = now
+	s.dirty = true
+}
+
+// Score implements PeerScorer.
+func (s *persistentPeerScorer) Score(key string) float64 {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	e, ok := s.scores[key]
+	if !ok {
+		return 0
+	}
+	return s.decayedScore(e, time.Now())
+}
+
+// Ban implements PeerScorer.
+func (s *persistentPeerScorer) Ban(key string, duration time.Duration) {
+	now := time.Now()
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	e, ok := s.scores[key]
+	if !ok {
+		e = &peerScoreEntry{UpdatedAt: now}
+		s.scores[key] = e
+	}
+	if duration <= 0 {
+		e.Permanent = true
+	} else {
+		e.BannedUntil = now.Add(duration)
+	}
+	s.dirty = true
+}
+
+// IsBanned implements PeerScorer.
+func (s *persistentPeerScorer) IsBanned(key string) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	e, ok := s.scores[key]
+	if !ok {
+		return false
+	}
+	return e.Permanent || time.Now().Before(e.BannedUntil)
+}
+
+// saveLocked persists scores to disk, if configured. Errors are dropped:
+// reputation tracking is a best-effort defense, not a correctness
+// requirement, and failing a handshake because the scorer can't write to
+// disk would be worse than not persisting.
+func (s *persistentPeerScorer) saveLocked() {
+	if s.persistTo == "" {
+		return
+	}
+	bz, err := json.Marshal(s.scores)
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(s.persistTo, bz, 0o600)
+}
+
+// load reads previously persisted scores from disk, if the file exists.
+func (s *persistentPeerScorer) load() error {
+	bz, err := os.ReadFile(s.persistTo)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return json.Unmarshal(bz, &s.scores)
+}
+
+// ConnScoreFilter returns a ConnFilterFunc that rejects an inbound TCP
+// connection if any of its resolved IP addresses are banned or score below
+// threshold. It composes with ConnDuplicateIPFilter and other
+// ConnFilterFuncs via MConnTransportConnFilters.
+func ConnScoreFilter(scorer PeerScorer, threshold float64) ConnFilterFunc {
+	return func(_ ConnSet, c net.Conn, ips []net.IP) error {
+		for _, ip := range ips {
+			key := ip.String()
+			if scorer.IsBanned(key) {
+				return ErrRejected{conn: c, err: fmt.Errorf("ip<%v> is banned", ip), isFiltered: true}
+			}
+			if score := scorer.Score(key); score < threshold {
+				return ErrRejected{
+					conn:       c,
+					err:        fmt.Errorf("ip<%v> score %.1f below threshold %.1f", ip, score, threshold),
+					isFiltered: true,
+				}
+			}
+		}
+		return nil
+	}
+}
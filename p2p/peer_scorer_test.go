@@ -0,0 +1,56 @@
+package p2p
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPersistentPeerScorerDecayedScore(t *testing.T) {
+	s := &persistentPeerScorer{halfLife: time.Hour}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name    string
+		elapsed time.Duration
+		want    float64
+	}{
+		{"no time elapsed", 0, -10},
+		{"one half-life", time.Hour, -5},
+		{"two half-lives", 2 * time.Hour, -2.5},
+		{"negative elapsed is clamped", -time.Hour, -10},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			e := &peerScoreEntry{Score: -10, UpdatedAt: start}
+			got := s.decayedScore(e, start.Add(tc.elapsed))
+			if math.Abs(got-tc.want) > 1e-9 {
+				t.Fatalf("decayedScore() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPersistentPeerScorerRecordAndBan(t *testing.T) {
+	s := &persistentPeerScorer{
+		scores:   make(map[string]*peerScoreEntry),
+		halfLife: defaultScoreHalfLife,
+		chClose:  make(chan struct{}),
+		chClosed: make(chan struct{}),
+	}
+	close(s.chClosed)
+
+	s.Record("peerA", EventHandshakeFailure)
+	if score := s.Score("peerA"); score >= 0 {
+		t.Fatalf("Score() = %v, want negative after a recorded event", score)
+	}
+	if s.IsBanned("peerA") {
+		t.Fatalf("IsBanned() = true, want false before any ban")
+	}
+
+	s.Ban("peerA", time.Minute)
+	if !s.IsBanned("peerA") {
+		t.Fatalf("IsBanned() = false, want true after Ban")
+	}
+}
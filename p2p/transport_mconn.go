@@ -7,6 +7,7 @@ import (
 	"io"
 	"math"
 	"net"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/tendermint/tendermint/libs/log"
 	"github.com/tendermint/tendermint/libs/protoio"
 	tmconn "github.com/tendermint/tendermint/p2p/conn"
+	"github.com/tendermint/tendermint/p2p/nat"
 	p2pproto "github.com/tendermint/tendermint/proto/tendermint/p2p"
 
 	"golang.org/x/net/netutil"
@@ -23,6 +25,13 @@ const (
 	defaultDialTimeout      = time.Second
 	defaultFilterTimeout    = 5 * time.Second
 	defaultHandshakeTimeout = 3 * time.Second
+
+	// natMappingLifetime is the lease duration requested for a NAT port
+	// mapping. NAT-PMP leases in particular are short-lived (RFC 6886
+	// recommends renewing well before expiry), so the mapping is refreshed
+	// well within this window.
+	natMappingLifetime = 20 * time.Minute
+	natRefreshInterval = 15 * time.Minute
 )
 
 // MConnProtocol is the MConn protocol identifier.
@@ -47,6 +56,46 @@ func MConnTransportConnFilters(filters ...ConnFilterFunc) MConnTransportOption {
 	return func(mt *mConnTransport) { mt.connFilters = filters }
 }
 
+// MConnTransportNAT sets up NAT traversal (UPnP or NAT-PMP) for the listener,
+// requesting a port mapping on Listen and refreshing it periodically until
+// Close. The discovered external address is then reported via Endpoints().
+func MConnTransportNAT(n nat.Interface) MConnTransportOption {
+	return func(mt *mConnTransport) { mt.nat = n }
+}
+
+// MConnTransportResolver sets the resolver used to look up endpoints that
+// carry a DNS name instead of a literal IP address. Defaults to
+// net.DefaultResolver.
+func MConnTransportResolver(resolver IPResolver) MConnTransportOption {
+	return func(mt *mConnTransport) { mt.resolver = resolver }
+}
+
+// MConnTransportPeerScorer sets the PeerScorer used to automatically
+// penalize handshake failures and protocol violations, so misbehaving peers
+// are tracked without every reactor having to opt in, and to reject a peer
+// once its node ID is known and banned or scores below threshold. Combine
+// with ConnScoreFilter (passed to MConnTransportConnFilters) to also reject
+// by IP before the handshake even starts.
+func MConnTransportPeerScorer(scorer PeerScorer, threshold float64) MConnTransportOption {
+	return func(mt *mConnTransport) {
+		mt.scorer = scorer
+		mt.scoreThreshold = threshold
+	}
+}
+
+// IPResolver resolves a hostname to a set of IP addresses. It is satisfied by
+// *net.Resolver, and exists mainly to allow tests to substitute a fake
+// resolver.
+type IPResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// dialStagger is the delay between successive dial attempts against the
+// addresses returned for a hostname endpoint, in rough imitation of Happy
+// Eyeballs (RFC 8305): give the first address a head start before racing the
+// rest, rather than waiting out its full dial timeout on failure.
+const dialStagger = 200 * time.Millisecond
+
 // ConnFilterFunc is a callback for connection filtering. If it returns an
 // error, the connection is rejected. The set of existing connections is passed
 // along with the new connection and all resolved IPs.
@@ -94,6 +143,43 @@ type mConnTransport struct {
 	// by the router once we rewrite the P2P core.
 	conns       ConnSet
 	connFilters []ConnFilterFunc
+
+	nat        nat.Interface
+	natMu      sync.Mutex
+	natExtAddr *net.TCPAddr
+	chNATClose chan struct{}
+
+	resolver       IPResolver
+	scorer         PeerScorer
+	scoreThreshold float64
+}
+
+// recordScoreEvent reports event against every key associated with tcpConn
+// -- its remote IP, and its node ID once known -- if a PeerScorer is
+// configured. It is a no-op otherwise, so callers don't need to guard every
+// call site on mt.scorer being set.
+func (m *mConnTransport) recordScoreEvent(tcpConn net.Conn, id ID, event PeerScoreEvent) {
+	if m.scorer == nil {
+		return
+	}
+	if host, _, err := net.SplitHostPort(tcpConn.RemoteAddr().String()); err == nil {
+		m.scorer.Record(host, event)
+	}
+	if id != "" {
+		m.scorer.Record(string(id), event)
+	}
+}
+
+// recordScoreEventForIPs reports event against each of ips, for dial
+// failures where every candidate address was tried but none ever produced a
+// net.Conn to read a remote address from.
+func (m *mConnTransport) recordScoreEventForIPs(ips []net.IP, event PeerScoreEvent) {
+	if m.scorer == nil {
+		return
+	}
+	for _, ip := range ips {
+		m.scorer.Record(ip.String(), event)
+	}
 }
 
 // NewMConnTransport sets up a new MConn transport.
@@ -121,6 +207,9 @@ func NewMConnTransport(
 
 		conns:       NewConnSet(),
 		connFilters: []ConnFilterFunc{},
+
+		chNATClose: make(chan struct{}),
+		resolver:   net.DefaultResolver,
 	}
 	for _, opt := range opts {
 		opt(m)
@@ -154,9 +243,49 @@ func (m *mConnTransport) Listen(endpoint Endpoint) error {
 	// Spawn a goroutine to accept inbound connections asynchronously.
 	go m.accept()
 
+	if m.nat != nil {
+		go m.runNATMapping(endpoint.Port)
+	}
+
 	return nil
 }
 
+// runNATMapping requests a port mapping for intPort on the configured NAT
+// device and keeps it alive for as long as the transport is listening,
+// refreshing the lease well before it expires. The discovered external
+// address is published via Endpoints().
+func (m *mConnTransport) runNATMapping(intPort uint16) {
+	mapAndSet := func() {
+		if err := m.nat.AddMapping("tcp", int(intPort), int(intPort), "tendermint", natMappingLifetime); err != nil {
+			m.logger.Error("failed to set up NAT port mapping", "err", err)
+			return
+		}
+		extIP, err := m.nat.ExternalIP()
+		if err != nil {
+			m.logger.Error("failed to discover external IP via NAT", "err", err)
+			return
+		}
+		m.natMu.Lock()
+		m.natExtAddr = &net.TCPAddr{IP: extIP, Port: int(intPort)}
+		m.natMu.Unlock()
+		m.logger.Info("mapped port via NAT", "nat", m.nat, "addr", m.natExtAddr)
+	}
+
+	mapAndSet()
+	ticker := time.NewTicker(natRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			mapAndSet()
+		case <-m.chNATClose:
+			// Best-effort: the mapping is torn down on Close.
+			_ = m.nat.DeleteMapping("tcp", int(intPort), int(intPort))
+			return
+		}
+	}
+}
+
 // accept accepts inbound connections in a loop, and asynchronously handshakes
 // with the peer to avoid head-of-line blocking. Established connections are
 // passed to Accept() via the channel m.chAccept.
@@ -172,7 +301,7 @@ func (m *mConnTransport) accept() {
 			return
 		}
 		go func() {
-			err := m.filterTCPConn(tcpConn)
+			err := m.filterTCPConn(tcpConn, nil)
 			if err != nil {
 				_ = tcpConn.Close()
 				select {
@@ -220,15 +349,23 @@ func (m *mConnTransport) Dial(ctx context.Context, endpoint Endpoint) (Connectio
 		return nil, err
 	}
 
+	ips, err := m.resolveEndpointIPs(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, m.dialTimeout)
 	defer cancel()
-	dialer := net.Dialer{}
-	tcpConn, err := dialer.DialContext(ctx, "tcp", fmt.Sprintf("%v:%v", endpoint.IP, endpoint.Port))
+
+	tcpConn, err := m.dialAny(ctx, ips, endpoint.Port)
 	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			m.recordScoreEventForIPs(ips, EventDialTimeout)
+		}
 		return nil, err
 	}
 
-	err = m.filterTCPConn(tcpConn)
+	err = m.filterTCPConn(tcpConn, ips)
 	if err != nil {
 		return nil, err
 	}
@@ -242,31 +379,127 @@ func (m *mConnTransport) Dial(ctx context.Context, endpoint Endpoint) (Connectio
 	return conn, nil
 }
 
+// resolveEndpointIPs returns the set of candidate IP addresses for endpoint.
+// If the endpoint already carries a literal IP, that is the only candidate;
+// otherwise its Host is resolved via the transport's IPResolver.
+func (m *mConnTransport) resolveEndpointIPs(ctx context.Context, endpoint Endpoint) ([]net.IP, error) {
+	if len(endpoint.IP) > 0 {
+		return []net.IP{endpoint.IP}, nil
+	}
+	addrs, err := m.resolver.LookupIPAddr(ctx, endpoint.Host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %q: %w", endpoint.Host, err)
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("host %q has no addresses", endpoint.Host)
+	}
+	ips := make([]net.IP, len(addrs))
+	for i, addr := range addrs {
+		ips[i] = addr.IP
+	}
+	return ips, nil
+}
+
+// dialAny dials each of ips in turn, staggering attempts Happy-Eyeballs-style
+// so that a slow or unreachable first address doesn't block trying the rest,
+// and returns the first connection to succeed.
+func (m *mConnTransport) dialAny(ctx context.Context, ips []net.IP, port uint16) (net.Conn, error) {
+	results := make(chan dialAnyResult, len(ips))
+	dialer := net.Dialer{}
+
+	for i, ip := range ips {
+		i, ip := i, ip
+		go func() {
+			select {
+			case <-time.After(time.Duration(i) * dialStagger):
+			case <-ctx.Done():
+				results <- dialAnyResult{err: ctx.Err()}
+				return
+			}
+			conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), fmt.Sprint(port)))
+			results <- dialAnyResult{conn: conn, err: err}
+		}()
+	}
+
+	var errs []error
+	for i := 0; i < len(ips); i++ {
+		res := <-results
+		if res.err == nil {
+			// Other staggered dials may still be in flight and succeed
+			// after we've already returned the winner; drain and close
+			// them in the background instead of leaking their sockets.
+			go drainDialResults(results, len(ips)-i-1)
+			return res.conn, nil
+		}
+		errs = append(errs, res.err)
+	}
+	return nil, fmt.Errorf("failed to dial any of %v: %v", ips, errs)
+}
+
+// dialAnyResult is the outcome of one candidate dial in dialAny.
+type dialAnyResult struct {
+	conn net.Conn
+	err  error
+}
+
+// drainDialResults reads the remaining n dial results off results and closes
+// any connection that arrived too late to be used, so a slower successful
+// candidate doesn't leak its socket for the life of the process.
+func drainDialResults(results <-chan dialAnyResult, n int) {
+	for i := 0; i < n; i++ {
+		if res := <-results; res.conn != nil {
+			_ = res.conn.Close()
+		}
+	}
+}
+
 // Endpoints implements Transport.
 func (m *mConnTransport) Endpoints() []Endpoint {
 	if m.listener == nil {
 		return []Endpoint{}
 	}
 	addr := m.listener.Addr().(*net.TCPAddr)
-	return []Endpoint{{
+	endpoints := []Endpoint{{
 		Protocol: MConnProtocol,
 		PeerID:   m.nodeInfo.ID(),
 		IP:       addr.IP,
 		Port:     uint16(addr.Port),
 	}}
+
+	m.natMu.Lock()
+	natAddr := m.natExtAddr
+	m.natMu.Unlock()
+	if natAddr != nil && !natAddr.IP.Equal(addr.IP) {
+		endpoints = append(endpoints, Endpoint{
+			Protocol: MConnProtocol,
+			PeerID:   m.nodeInfo.ID(),
+			IP:       natAddr.IP,
+			Port:     uint16(natAddr.Port),
+		})
+	}
+	return endpoints
 }
 
 // Close implements Transport.
 func (m *mConnTransport) Close() error {
-	m.chCloseOnce.Do(func() { close(m.chClose) })
+	m.chCloseOnce.Do(func() {
+		close(m.chClose)
+		if m.nat != nil {
+			close(m.chNATClose)
+		}
+	})
 	if m.listener != nil {
 		return m.listener.Close()
 	}
 	return nil
 }
 
-// filterTCPConn filters a TCP connection, rejecting it if this function errors.
-func (m *mConnTransport) filterTCPConn(tcpConn net.Conn) error {
+// filterTCPConn filters a TCP connection, rejecting it if this function
+// errors. resolvedIPs carries every address a dialed hostname resolved to
+// (nil for inbound connections), so that ConnDuplicateIPFilter sees all of
+// them -- otherwise a peer could return multiple A records and bypass
+// duplicate-IP protection by connecting from one and advertising another.
+func (m *mConnTransport) filterTCPConn(tcpConn net.Conn, resolvedIPs []net.IP) error {
 
 	if m.conns.Has(tcpConn) {
 		return ErrRejected{conn: tcpConn, isDuplicate: true}
@@ -280,12 +513,13 @@ func (m *mConnTransport) filterTCPConn(tcpConn net.Conn) error {
 	if ip == nil {
 		return fmt.Errorf("connection address has invalid IP address %q", host)
 	}
+	ips := append([]net.IP{ip}, resolvedIPs...)
 
 	// Apply filter callbacks.
 	chErr := make(chan error, len(m.connFilters))
 	for _, connFilter := range m.connFilters {
 		go func(connFilter ConnFilterFunc) {
-			chErr <- connFilter(m.conns, tcpConn, []net.IP{ip})
+			chErr <- connFilter(m.conns, tcpConn, ips)
 		}(connFilter)
 	}
 
@@ -296,6 +530,7 @@ func (m *mConnTransport) filterTCPConn(tcpConn net.Conn) error {
 				return ErrRejected{conn: tcpConn, err: err, isFiltered: true}
 			}
 		case <-time.After(m.filterTimeout):
+			m.recordScoreEvent(tcpConn, "", EventFilterTimeout)
 			return ErrFilterTimeout{}
 		}
 
@@ -304,7 +539,7 @@ func (m *mConnTransport) filterTCPConn(tcpConn net.Conn) error {
 	// FIXME Doesn't really make sense to set this here, but we preserve the
 	// behavior from the previous P2P transport implementation. This should
 	// be moved to the router.
-	m.conns.Set(tcpConn, []net.IP{ip})
+	m.conns.Set(tcpConn, ips)
 	return nil
 }
 
@@ -322,8 +557,8 @@ func (m *mConnTransport) normalizeEndpoint(endpoint *Endpoint) error {
 	if endpoint.Protocol != MConnProtocol {
 		return fmt.Errorf("unsupported protocol %q", endpoint.Protocol)
 	}
-	if len(endpoint.IP) == 0 {
-		return errors.New("endpoint must have an IP address")
+	if len(endpoint.IP) == 0 && endpoint.Host == "" {
+		return errors.New("endpoint must have an IP address or host")
 	}
 	if endpoint.Path != "" {
 		return fmt.Errorf("endpoint cannot have path (got %q)", endpoint.Path)
@@ -352,6 +587,21 @@ func newMConnConnection(
 	tcpConn net.Conn,
 	expectPeerID ID,
 ) (conn *mConnConnection, err error) {
+	// Penalize the remote IP (and, once known, node ID) for any handshake
+	// failure, so misbehaving or flaky peers accumulate a worse score
+	// without every call site having to remember to report it. Declared
+	// before the recover() defer below so it runs after, and observes the
+	// final err.
+	defer func() {
+		if err != nil {
+			id := ID("")
+			if conn != nil {
+				id = conn.nodeInfo.ID()
+			}
+			transport.recordScoreEvent(tcpConn, id, EventHandshakeFailure)
+		}
+	}()
+
 	// FIXME Since the MConnection code panics, we need to recover here
 	// and turn it into an error. Be careful not to alias err, so we can
 	// update it from within this function.
@@ -450,6 +700,31 @@ func newMConnConnection(
 		return
 	}
 
+	// Now that the node ID is known, reject peers the scorer has banned or
+	// marked down from prior misbehavior, rather than waiting for
+	// ConnScoreFilter to catch them by IP on a later connection attempt.
+	if transport.scorer != nil {
+		id := conn.nodeInfo.ID()
+		if transport.scorer.IsBanned(string(id)) {
+			err = ErrRejected{
+				conn:       tcpConn,
+				id:         id,
+				err:        fmt.Errorf("peer %v is banned", id),
+				isFiltered: true,
+			}
+			return
+		}
+		if score := transport.scorer.Score(string(id)); score < transport.scoreThreshold {
+			err = ErrRejected{
+				conn:       tcpConn,
+				id:         id,
+				err:        fmt.Errorf("peer %v score %.1f below threshold %.1f", id, score, transport.scoreThreshold),
+				isFiltered: true,
+			}
+			return
+		}
+	}
+
 	err = tcpConn.SetDeadline(time.Time{})
 	if err != nil {
 		err = ErrRejected{
@@ -518,9 +793,26 @@ func (c *mConnConnection) onReceive(chID byte, bz []byte, eof bool) error {
 func (c *mConnConnection) onError(err interface{}) {
 	// FIXME Probably need to do something better here
 	c.logger.Error("connection failure", "err", err)
+	c.transport.recordScoreEvent(c.secretConn, c.nodeInfo.ID(), classifyConnError(err))
 	_ = c.Close()
 }
 
+// oversizedMessageErrSubstring is the text tmconn.MConnection's recvRoutine
+// uses when a peer sends a message larger than the configured limit. There
+// is no typed sentinel error for it to check with errors.As, so matching on
+// this is the most specific signal onError has available.
+const oversizedMessageErrSubstring = "max message size"
+
+// classifyConnError maps an error surfaced by MConnection's onError callback
+// to the PeerScoreEvent it represents, so a peer sending an oversized
+// message is scored differently from an arbitrary protocol violation.
+func classifyConnError(err interface{}) PeerScoreEvent {
+	if e, ok := err.(error); ok && strings.Contains(e.Error(), oversizedMessageErrSubstring) {
+		return EventOversizedMessage
+	}
+	return EventProtocolViolation
+}
+
 // NodeInfo implements Connection.
 func (c *mConnConnection) NodeInfo() DefaultNodeInfo {
 	return c.nodeInfo
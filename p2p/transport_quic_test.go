@@ -0,0 +1,101 @@
+package p2p
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+func TestQUICTransportTLSConfigMemoized(t *testing.T) {
+	q := &quicTransport{}
+
+	conf1, err := q.tlsConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	conf2, err := q.tlsConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if conf1 != conf2 {
+		t.Fatalf("tlsConfig returned a different *tls.Config on the second call")
+	}
+	if conf1.ClientSessionCache != conf2.ClientSessionCache {
+		t.Fatalf("tlsConfig returned a different ClientSessionCache on the second call, 0-RTT resumption can't work")
+	}
+}
+
+// TestQUICTransportDialAcceptEndToEnd actually dials a listening
+// quicTransport rather than just inspecting tlsConfig, so it would have
+// caught every Dial failing because the listener's self-signed certificate
+// wasn't trusted by the dialing side (InsecureSkipVerify unset).
+func TestQUICTransportDialAcceptEndToEnd(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	channels := []*ChannelDescriptor{{ID: 1}}
+
+	serverKey := ed25519.GenPrivKey()
+	server := NewQUICTransport(log.NewNopLogger(), testQUICNodeInfo(serverKey, "server"), serverKey).(*quicTransport)
+	server.channelDescs = channels
+	if err := server.Listen(Endpoint{Protocol: QUICProtocol, IP: net.ParseIP("127.0.0.1")}); err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer server.Close()
+
+	serverEndpoint := server.Endpoints()[0]
+	serverEndpoint.IP = net.ParseIP("127.0.0.1")
+
+	clientKey := ed25519.GenPrivKey()
+	clientInfo := testQUICNodeInfo(clientKey, "client")
+	client := NewQUICTransport(log.NewNopLogger(), clientInfo, clientKey).(*quicTransport)
+	client.channelDescs = channels
+	defer client.Close()
+
+	chAccepted := make(chan Connection, 1)
+	chAcceptErr := make(chan error, 1)
+	go func() {
+		conn, err := server.Accept(ctx)
+		if err != nil {
+			chAcceptErr <- err
+			return
+		}
+		chAccepted <- conn
+	}()
+
+	clientConn, err := client.Dial(ctx, serverEndpoint)
+	if err != nil {
+		t.Fatalf("Dial failed (an untrusted self-signed cert would fail exactly here): %v", err)
+	}
+	defer clientConn.Close()
+
+	select {
+	case err := <-chAcceptErr:
+		t.Fatalf("Accept failed: %v", err)
+	case serverConn := <-chAccepted:
+		defer serverConn.Close()
+		if serverConn.NodeInfo().ID() != clientInfo.ID() {
+			t.Fatalf("server accepted peer ID %v, want %v", serverConn.NodeInfo().ID(), clientInfo.ID())
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the server to accept the dialed connection")
+	}
+}
+
+// testQUICNodeInfo builds a minimal, valid DefaultNodeInfo for privKey, for
+// use as both ends' identity in end-to-end transport tests.
+func testQUICNodeInfo(privKey crypto.PrivKey, moniker string) DefaultNodeInfo {
+	return DefaultNodeInfo{
+		DefaultNodeID: PubKeyToID(privKey.PubKey()),
+		ListenAddr:    "127.0.0.1:0",
+		Network:       "test-chain",
+		Version:       "1.0.0",
+		Moniker:       moniker,
+	}
+}